@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/containers/common/pkg/retry"
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+	imgremote "github.com/containers/skopeo/signature/remote"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// copyOptions contains information retrieved from the skopeo copy command line.
+type copyOptions struct {
+	global              *globalOptions
+	deprecatedTLSVerify *deprecatedTLSVerifyOption
+	srcImage            *imageOptions
+	destImage           *imageDestOptions
+	retryOpts           *retry.RetryOptions
+	additionalTags      []string       // For docker-archive: destination, additional tags to add to the archive
+	removeSignatures    bool           // Do not copy signatures from the source image
+	signByFingerprint   string         // Sign the image using a GPG key with the specified fingerprint
+	signPassphraseFile  string         // Path to a file containing the passphrase for the signing key
+	signByRemote        string         // exec:… or http(s):// spec of a remote signer, see --sign-by-remote
+	signIdentity        optionalString // Identity to use when signing, instead of the destination reference
+	format              optionalString // Force conversion of the image to a specified format
+	quiet               bool           // Suppress output information when copying images
+	all                 bool           // Copy all of the images if the source is a list
+}
+
+func copyCmd(global *globalOptions) *cobra.Command {
+	sharedFlags, sharedOpts := sharedImageFlags()
+	deprecatedTLSVerifyFlags, deprecatedTLSVerifyOpt := deprecatedTLSVerifyFlags()
+	srcFlags, srcOpts := imageFlags(global, sharedOpts, deprecatedTLSVerifyOpt, "src-", "screds")
+	destFlags, destOpts := imageDestFlags(global, sharedOpts, deprecatedTLSVerifyOpt, "dest-", "dcreds")
+	retryFlags, retryOpts := retryFlags()
+	opts := copyOptions{
+		global:              global,
+		deprecatedTLSVerify: deprecatedTLSVerifyOpt,
+		srcImage:            srcOpts,
+		destImage:           destOpts,
+		retryOpts:           retryOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "copy [command options] SOURCE-IMAGE DESTINATION-IMAGE",
+		Short:   "Copy an IMAGE-NAME from one location to another",
+		Long:    "Container `image` copy copies images between registries and local directories.\n\nSee skopeo(1) section \"IMAGE NAMES\" for the expected format",
+		RunE:    commandAction(opts.run),
+		Example: `skopeo copy docker://registry.example.com/example/image:latest docker://registry.example.com/example/copy`,
+	}
+	adjustUsage(cmd)
+	flags := cmd.Flags()
+	flags.AddFlagSet(&sharedFlags)
+	flags.AddFlagSet(&deprecatedTLSVerifyFlags)
+	flags.AddFlagSet(&srcFlags)
+	flags.AddFlagSet(&destFlags)
+	flags.AddFlagSet(&retryFlags)
+	flags.StringSliceVar(&opts.additionalTags, "additional-tag", []string{}, "additional tags (supports docker-archive)")
+	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Suppress output information when copying images")
+	flags.BoolVar(&opts.removeSignatures, "remove-signatures", false, "Do not copy signatures from SOURCE-IMAGE")
+	flags.StringVar(&opts.signByFingerprint, "sign-by", "", "Sign the image using a GPG key with the specified `FINGERPRINT`")
+	flags.StringVar(&opts.signPassphraseFile, "passphrase-file", "", "read the passphrase for the signing key from `FILE`")
+	flags.StringVar(&opts.signByRemote, "sign-by-remote", "", "sign using a remote signer, with the key named by --sign-by; `SPEC` is exec:/path/to/signer or http(s)://server/sign")
+	flags.Var(newOptionalStringValue(&opts.signIdentity), "sign-identity", "`REFERENCE` to use as the signed identity, instead of the destination reference")
+	flags.VarP(newOptionalStringValue(&opts.format), "format", "f", `MANIFEST TYPE (oci, v2s1, or v2s2) to use in the destination (default is manifest type of source, with fallbacks)`)
+	flags.BoolVarP(&opts.all, "all", "a", false, "Copy all images if SOURCE-IMAGE is a list")
+	return cmd
+}
+
+func (opts *copyOptions) run(args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return errorShouldDisplayUsage{errors.New("Exactly two arguments expected")}
+	}
+	opts.deprecatedTLSVerify.warnIfUsed([]string{"--src-tls-verify", "--dest-tls-verify"})
+
+	imageListSelection := copy.CopySystemImage
+	if opts.all {
+		imageListSelection = copy.CopyAllImages
+	}
+
+	policyContext, err := opts.global.getPolicyContext()
+	if err != nil {
+		return errors.Wrapf(err, "Error loading trust policy")
+	}
+	defer policyContext.Destroy()
+
+	srcRef, err := alltransports.ParseImageName(args[0])
+	if err != nil {
+		return errors.Wrapf(err, "Invalid source name %s", args[0])
+	}
+	destRef, err := alltransports.ParseImageName(args[1])
+	if err != nil {
+		return errors.Wrapf(err, "Invalid destination name %s", args[1])
+	}
+
+	sourceCtx, err := opts.srcImage.newSystemContext()
+	if err != nil {
+		return err
+	}
+	destinationCtx, err := opts.destImage.newSystemContext()
+	if err != nil {
+		return err
+	}
+
+	var manifestType string
+	if opts.format.present {
+		manifestType, err = parseManifestFormat(opts.format.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	var signIdentity reference.Named
+	if opts.signIdentity.present {
+		signIdentity, err = reference.ParseNamed(opts.signIdentity.value)
+		if err != nil {
+			return errors.Wrapf(err, "Error parsing --sign-identity")
+		}
+	}
+
+	var signPassphrase string
+	if opts.signByFingerprint != "" {
+		signPassphrase, err = passphraseFromArgs(opts.signPassphraseFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	remoteSign := opts.signByRemote != ""
+	var signingMechanism signature.SigningMechanism
+	if remoteSign {
+		if opts.signByFingerprint == "" {
+			return errorShouldDisplayUsage{errors.New("--sign-by-remote requires --sign-by to name the key identity known to the remote signer")}
+		}
+		signingMechanism, err = imgremote.NewSigningMechanism(opts.signByRemote, destinationCtx)
+		if err != nil {
+			return errors.Wrap(err, "Error initializing remote signing mechanism")
+		}
+		defer signingMechanism.Close()
+	}
+
+	ctx, cancel := opts.global.commandTimeoutContext()
+	defer cancel()
+
+	var reportWriter io.Writer = os.Stdout
+	if opts.quiet {
+		reportWriter = nil
+	}
+
+	copyOptions := &copy.Options{
+		// copy.Image has no way to sign with a caller-supplied SigningMechanism,
+		// so a remote signer's signature is produced and attached separately,
+		// below, after the copy itself has completed.
+		RemoveSignatures:                      opts.removeSignatures || remoteSign,
+		ReportWriter:                          reportWriter,
+		SourceCtx:                             sourceCtx,
+		DestinationCtx:                        destinationCtx,
+		ForceManifestMIMEType:                 manifestType,
+		ImageListSelection:                    imageListSelection,
+		OptimizeDestinationImageAlreadyExists: true,
+	}
+	if !remoteSign {
+		copyOptions.SignBy = opts.signByFingerprint
+		copyOptions.SignPassphrase = signPassphrase
+		copyOptions.SignIdentity = signIdentity
+	}
+
+	return retry.RetryIfNecessary(ctx, func() error {
+		manifestBytes, err := copy.Image(ctx, policyContext, destRef, srcRef, copyOptions)
+		if err != nil {
+			return err
+		}
+		if !remoteSign {
+			return nil
+		}
+		return signAndAttachRemote(ctx, destRef, destinationCtx, manifestBytes, signIdentity, signingMechanism, opts.signByFingerprint, signPassphrase)
+	}, opts.retryOpts)
+}
+
+// signAndAttachRemote signs manifestBytes with mech (a remote signing
+// mechanism) under identity (defaulting to destRef's own reference, if nil),
+// and uploads the resulting detached signature to destRef. It exists because
+// copy.Options has no field to hand copy.Image a caller-supplied
+// SigningMechanism: unlike local GPG signing, remote signing must happen as
+// a separate step after the image itself has been copied.
+func signAndAttachRemote(ctx context.Context, destRef types.ImageReference, destinationCtx *types.SystemContext, manifestBytes []byte, identity reference.Named, mech signature.SigningMechanism, fingerprint string, passphrase string) error {
+	if identity == nil {
+		identity = destRef.DockerReference()
+	}
+	if identity == nil {
+		return errors.New("Cannot determine the destination's signed identity for --sign-by-remote; use --sign-identity to set one explicitly")
+	}
+
+	sig, err := signature.SignDockerManifestWithOptions(manifestBytes, identity.String(), mech, fingerprint, &signature.SignOptions{Passphrase: passphrase})
+	if err != nil {
+		return errors.Wrap(err, "Error creating remote signature")
+	}
+
+	dest, err := destRef.NewImageDestination(ctx, destinationCtx)
+	if err != nil {
+		return errors.Wrap(err, "Error opening destination to upload remote signature")
+	}
+	defer dest.Close()
+
+	if err := dest.SupportsSignatures(ctx); err != nil {
+		return errors.Wrap(err, "Destination does not support signatures")
+	}
+	if err := dest.PutSignatures(ctx, [][]byte{sig}, nil); err != nil {
+		return errors.Wrap(err, "Error uploading remote signature")
+	}
+	return dest.Commit(ctx, nil)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+// signPassphraseEnvVar is the name of the environment variable that, if set,
+// provides the passphrase for a signing key when --passphrase-file is not used.
+const signPassphraseEnvVar = "SKOPEO_SIGN_PASSPHRASE"
+
+// passphraseFromArgs resolves the passphrase to use for signing: it prefers
+// passphraseFile (as set by --passphrase-file), falls back to the
+// SKOPEO_SIGN_PASSPHRASE environment variable, and as a last resort prompts
+// interactively if standard input is a terminal. It returns an empty string
+// if none of these apply, meaning the key is expected to need no passphrase.
+func passphraseFromArgs(passphraseFile string) (string, error) {
+	if passphraseFile != "" {
+		p, err := ioutil.ReadFile(passphraseFile)
+		if err != nil {
+			return "", errors.Wrapf(err, "Error reading passphrase file %s", passphraseFile)
+		}
+		return strings.TrimRight(string(p), "\n"), nil
+	}
+	if p, ok := os.LookupEnv(signPassphraseEnvVar); ok {
+		return p, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", nil
+	}
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", errors.Wrap(err, "Error reading passphrase")
+	}
+	return strings.TrimSpace(string(passphrase)), nil
+}
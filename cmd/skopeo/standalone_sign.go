@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/containers/image/v5/signature"
+	imgremote "github.com/containers/skopeo/signature/remote"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// standaloneSignOptions contains information retrieved from the skopeo standalone-sign command line.
+type standaloneSignOptions struct {
+	output         string         // Output file path
+	passphraseFile string         // Path to a file containing the passphrase for the signing key
+	signByRemote   string         // exec:… or http(s):// spec of a remote signer, see --sign-by-remote
+	signIdentity   optionalString // Identity to use when signing, instead of the DOCKER-REFERENCE argument
+	signingBackend string         // Name of the local GPG signing backend to use, see --signing-backend
+}
+
+func standaloneSignCmd() *cobra.Command {
+	opts := standaloneSignOptions{}
+	cmd := &cobra.Command{
+		Use:     "standalone-sign [command options] MANIFEST DOCKER-REFERENCE KEY-FINGERPRINT",
+		Short:   "Create a signature using local files",
+		Long:    "Sign MANIFEST with KEY-FINGERPRINT, using DOCKER-REFERENCE as the signed content's identity, unless overridden with --sign-identity.",
+		RunE:    commandAction(opts.run),
+		Args:    cobra.ExactArgs(3),
+		Example: `skopeo standalone-sign -o cirros.signature cirros.manifest.json registry.example.com/cirros:latest FINGERPRINT`,
+	}
+	adjustUsage(cmd)
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.output, "output", "o", "", "output the signature to `FILE`")
+	flags.StringVar(&opts.passphraseFile, "passphrase-file", "", "read the passphrase for the signing key from `FILE`")
+	flags.StringVar(&opts.signByRemote, "sign-by-remote", "", "sign using a remote signer, `SPEC` is exec:/path/to/signer or http(s)://server/sign")
+	flags.Var(newOptionalStringValue(&opts.signIdentity), "sign-identity", "`REFERENCE` to use as the signed identity, instead of the DOCKER-REFERENCE argument")
+	flags.StringVar(&opts.signingBackend, "signing-backend", "", "name of the local GPG signing backend; only `gpgme` is accepted, and it does not change the backend used, which is always the one skopeo was built with")
+	return cmd
+}
+
+func (opts *standaloneSignOptions) run(args []string, stdout io.Writer) error {
+	if len(opts.output) == 0 {
+		return errorShouldDisplayUsage{errors.New("you must specify an output file path with -o")}
+	}
+	manifestPath, dockerReference, fingerprint := args[0], args[1], args[2]
+
+	identity := dockerReference
+	if opts.signIdentity.present {
+		identity = opts.signIdentity.value
+	}
+
+	passphrase, err := passphraseFromArgs(opts.passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return errors.Wrapf(err, "Error reading %s", manifestPath)
+	}
+
+	if opts.signingBackend != "" && opts.signingBackend != "gpgme" {
+		return errorShouldDisplayUsage{errors.Errorf("Unsupported --signing-backend %q: only %q is accepted; the choice between gpgme and the native Go OpenPGP implementation is fixed at compile time via the containers_image_openpgp build tag, and there is no \"sequoia\" backend", opts.signingBackend, "gpgme")}
+	}
+
+	var mech signature.SigningMechanism
+	if opts.signByRemote != "" {
+		mech, err = imgremote.NewSigningMechanism(opts.signByRemote, nil)
+	} else {
+		mech, err = signature.NewGPGSigningMechanism()
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error initializing a signing mechanism")
+	}
+	defer mech.Close()
+
+	var sig []byte
+	if passphrase != "" {
+		sig, err = signature.SignDockerManifestWithOptions(manifest, identity, mech, fingerprint, &signature.SignOptions{Passphrase: passphrase})
+	} else {
+		sig, err = signature.SignDockerManifest(manifest, identity, mech, fingerprint)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Error creating signature")
+	}
+
+	if err := ioutil.WriteFile(opts.output, sig, 0644); err != nil {
+		return errors.Wrapf(err, "Error writing signature to %s", opts.output)
+	}
+	return nil
+}
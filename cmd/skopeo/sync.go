@@ -1,7 +1,9 @@
 package main
 
 import (
+	"archive/tar"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,16 +11,32 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/containers/common/pkg/retry"
 	"github.com/containers/image/v5/copy"
 	"github.com/containers/image/v5/directory"
 	"github.com/containers/image/v5/docker"
+	dockerArchive "github.com/containers/image/v5/docker/archive"
 	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/manifest"
+	ociArchive "github.com/containers/image/v5/oci/archive"
+	ociLayout "github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/pkg/shortnames"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
 	"github.com/containers/image/v5/transports"
 	"github.com/containers/image/v5/types"
+	encconfig "github.com/containers/ocicrypt/config"
+	"github.com/containers/ocicrypt/helpers"
+	"github.com/hashicorp/go-multierror"
 	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -39,13 +57,21 @@ type syncOptions struct {
 	destination         string         // Destination registry name
 	scoped              bool           // When true, namespace copied images at destination using the source repository name
 	all                 bool           // Copy all of the images if an image in the source is a list
+	workers             int            // Number of concurrent image copies
+	encryptionKeys      []string       // Keys needed to encrypt the image
+	decryptionKeys      []string       // Keys needed to decrypt the image
+	encryptLayer        []int          // The list of layers to encrypt
+	pullPolicy          string         // One of pullPolicyAlways, pullPolicyMissing, pullPolicyNewer
 }
 
 // repoDescriptor contains information of a single repository used as a sync source.
 type repoDescriptor struct {
-	DirBasePath string                 // base path when source is 'dir'
-	ImageRefs   []types.ImageReference // List of tagged image found for the repository
-	Context     *types.SystemContext   // SystemContext for the sync command
+	DirBasePath   string                   // base path when source is 'dir'
+	ImageRefs     []types.ImageReference   // List of tagged image found for the repository
+	Context       *types.SystemContext     // SystemContext for the sync command
+	EncryptConfig *encconfig.EncryptConfig // Per-registry override for --encryption-key, from the YAML "encryption-keys" entry
+	DecryptConfig *encconfig.DecryptConfig // Per-registry override for --decryption-key, from the YAML "decryption-keys" entry
+	Platforms     []string                 // Manifest list platforms to keep, from the YAML "platforms" entry
 }
 
 // tlsVerifyConfig is an implementation of the Unmarshaler interface, used to
@@ -60,8 +86,14 @@ type registrySyncConfig struct {
 	Images           map[string][]string    // Images map images name to slices with the images' references (tags, digests)
 	ImagesByTagRegex map[string]string      `yaml:"images-by-tag-regex"` // Images map images name to regular expression with the images' tags
 	Credentials      types.DockerAuthConfig // Username and password used to authenticate with the registry
-	TLSVerify        tlsVerifyConfig        `yaml:"tls-verify"` // TLS verification mode (enabled by default)
-	CertDir          string                 `yaml:"cert-dir"`   // Path to the TLS certificates of the registry
+	TLSVerify        tlsVerifyConfig        `yaml:"tls-verify"`      // TLS verification mode (enabled by default)
+	CertDir          string                 `yaml:"cert-dir"`        // Path to the TLS certificates of the registry
+	EncryptionKeys   []string               `yaml:"encryption-keys"` // Keys needed to encrypt the images found in this registry
+	DecryptionKeys   []string               `yaml:"decryption-keys"` // Keys needed to decrypt the images found in this registry
+	ExcludeTags      []string               `yaml:"exclude-tags"`    // Regular expressions matching tags to drop, applied after Images/ImagesByTagRegex selection
+	Semver           string                 `yaml:"semver"`          // Semantic version constraint (e.g. ">=1.20 <2.0") tags must satisfy to be kept
+	LastN            int                    `yaml:"last-n"`          // Keep only the LastN most recently created tags
+	Platforms        []string               `yaml:"platforms"`       // Manifest list platforms to keep (e.g. "linux/amd64"), used together with --all
 }
 
 // sourceConfig contains all registries information read from the source YAML file
@@ -87,8 +119,8 @@ func syncCmd(global *globalOptions) *cobra.Command {
 		Short: "Synchronize one or more images from one location to another",
 		Long: `Copy all the images from a SOURCE to a DESTINATION.
 
-Allowed SOURCE transports (specified with --src): docker, dir, yaml.
-Allowed DESTINATION transports (specified with --dest): docker, dir.
+Allowed SOURCE transports (specified with --src): docker, dir, oci, oci-archive, docker-archive, yaml, registries-conf.
+Allowed DESTINATION transports (specified with --dest): docker, dir, oci, oci-archive, docker-archive.
 
 See skopeo-sync(1) for details.
 `,
@@ -104,6 +136,11 @@ See skopeo-sync(1) for details.
 	flags.StringVarP(&opts.destination, "dest", "d", "", "DESTINATION transport type")
 	flags.BoolVar(&opts.scoped, "scoped", false, "Images at DESTINATION are prefix using the full source image path as scope")
 	flags.BoolVarP(&opts.all, "all", "a", false, "Copy all images if SOURCE-IMAGE is a list")
+	flags.IntVar(&opts.workers, "workers", 1, "Number of images to copy in parallel")
+	flags.StringArrayVar(&opts.encryptionKeys, "encryption-key", []string{}, "*Experimental* key with the encryption protocol to use needed to encrypt the image (e.g. jwe:/path/to/key.pem)")
+	flags.IntSliceVar(&opts.encryptLayer, "encrypt-layer", []int{}, "*Experimental* the 0-indexed layer indices, with support for negative indexing (e.g. 0 is the first layer, -1 is the last layer)")
+	flags.StringArrayVar(&opts.decryptionKeys, "decryption-key", []string{}, "*Experimental* key needed to decrypt the images found in SOURCE")
+	flags.StringVar(&opts.pullPolicy, "policy", pullPolicyAlways, "skip images already at DESTINATION: `POLICY` is one of "+strings.Join(validPullPolicies, ", "))
 	flags.AddFlagSet(&sharedFlags)
 	flags.AddFlagSet(&deprecatedTLSVerifyFlags)
 	flags.AddFlagSet(&srcFlags)
@@ -153,6 +190,42 @@ func parseRepositoryReference(input string) (reference.Named, error) {
 	return ref, nil
 }
 
+// resolveRepositoryReference resolves input, which may be an unqualified
+// short name such as "alpine", into a fully-qualified repository reference,
+// consulting sys's registries.conf unqualified-search-registries list and
+// short-name aliases table. The resolution decision is logged. Inputs that
+// are already fully qualified are returned unchanged.
+func resolveRepositoryReference(sys *types.SystemContext, input string) (reference.Named, error) {
+	resolved, err := shortnames.Resolve(sys, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error resolving %q via registries.conf", input)
+	}
+	if desc := resolved.Description(); desc != "" {
+		logrus.Info(desc)
+	}
+	candidates := resolved.PullCandidates
+	if len(candidates) == 0 {
+		return nil, errors.Errorf("No registries.conf candidates found for %q", input)
+	}
+	return parseRepositoryReference(candidates[0].Value.Name())
+}
+
+// resolveImageName expands imageName (e.g. "alpine" or "library/alpine") via
+// registries.conf short-name aliases and the unqualified-search-registries
+// list, then qualifies the repository path that resolution found under
+// registryName, the specific registry this YAML entry configures
+// credentials and TLS settings for. Unlike resolveRepositoryReference alone,
+// this lets unqualified YAML image names actually be resolved, rather than
+// being handed to shortnames.Resolve already qualified with registryName
+// (which, having a "/", is never treated as an unqualified short name).
+func resolveImageName(sys *types.SystemContext, registryName string, imageName string) (reference.Named, error) {
+	resolved, err := resolveRepositoryReference(sys, imageName)
+	if err != nil {
+		return nil, err
+	}
+	return parseRepositoryReference(fmt.Sprintf("%s/%s", registryName, reference.Path(resolved)))
+}
+
 // destinationReference creates an image reference using the provided transport.
 // It returns a image reference to be used as destination of an image copy and
 // any error encountered.
@@ -176,6 +249,12 @@ func destinationReference(destination string, transport string) (types.ImageRefe
 			return nil, errors.Wrapf(err, "Error creating directory for image %s", destination)
 		}
 		imageTransport = directory.Transport
+	case ociLayout.Transport.Name():
+		imageTransport = ociLayout.Transport
+	case ociArchive.Transport.Name():
+		imageTransport = ociArchive.Transport
+	case dockerArchive.Transport.Name():
+		imageTransport = dockerArchive.Transport
 	default:
 		return nil, errors.Errorf("%q is not a valid destination transport", transport)
 	}
@@ -273,12 +352,301 @@ func imagesToCopyFromDir(dirPath string) ([]types.ImageReference, error) {
 	return sourceReferences, nil
 }
 
+// refsFromOCIIndex builds a slice of "base:tag" image references out of an OCI
+// index, one per manifest entry that carries an "org.opencontainers.image.ref.name"
+// annotation; entries without that annotation cannot be addressed individually
+// and are skipped.
+func refsFromOCIIndex(index imgspecv1.Index, base string, newReference func(refString string) (types.ImageReference, error)) ([]types.ImageReference, error) {
+	var sourceReferences []types.ImageReference
+	for _, manifest := range index.Manifests {
+		tag, ok := manifest.Annotations[imgspecv1.AnnotationRefName]
+		if !ok || tag == "" {
+			logrus.Debugf("Skipping manifest %s without an %s annotation", manifest.Digest, imgspecv1.AnnotationRefName)
+			continue
+		}
+		ref, err := newReference(fmt.Sprintf("%s:%s", base, tag))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot obtain a valid image reference for %q and tag %q", base, tag)
+		}
+		sourceReferences = append(sourceReferences, ref)
+	}
+	return sourceReferences, nil
+}
+
+// imagesToCopyFromOCILayout builds a list of image references from the tags
+// found in the index.json of an OCI layout directory.
+func imagesToCopyFromOCILayout(dirPath string) ([]types.ImageReference, error) {
+	indexPath := filepath.Join(dirPath, "index.json")
+	raw, err := ioutil.ReadFile(indexPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading %q", indexPath)
+	}
+	var index imgspecv1.Index
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return nil, errors.Wrapf(err, "Error parsing %q", indexPath)
+	}
+	return refsFromOCIIndex(index, dirPath, ociLayout.Transport.ParseReference)
+}
+
+// readArchiveMember extracts memberName from the tar archive at archivePath.
+func readArchiveMember(archivePath, memberName string) ([]byte, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error opening %q", archivePath)
+	}
+	defer f.Close()
+
+	reader := tar.NewReader(f)
+	for {
+		hdr, err := reader.Next()
+		if err == io.EOF {
+			return nil, errors.Errorf("%q not found in %q", memberName, archivePath)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error reading %q", archivePath)
+		}
+		if hdr.Name == memberName {
+			return ioutil.ReadAll(reader)
+		}
+	}
+}
+
+// imagesToCopyFromOCIArchive builds a list of image references from the tags
+// found in the index.json of an OCI archive (a tarball of an OCI layout).
+func imagesToCopyFromOCIArchive(archivePath string) ([]types.ImageReference, error) {
+	raw, err := readArchiveMember(archivePath, "index.json")
+	if err != nil {
+		return nil, err
+	}
+	var index imgspecv1.Index
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return nil, errors.Wrapf(err, "Error parsing index.json in %q", archivePath)
+	}
+	return refsFromOCIIndex(index, archivePath, ociArchive.Transport.ParseReference)
+}
+
+// dockerArchiveManifestItem mirrors the entries of the "manifest.json" found
+// at the root of a docker-archive tarball.
+type dockerArchiveManifestItem struct {
+	RepoTags []string `json:"RepoTags"`
+}
+
+// imagesToCopyFromDockerArchive builds a list of image references from the
+// repo tags found in the manifest.json of a docker-archive tarball.
+func imagesToCopyFromDockerArchive(archivePath string) ([]types.ImageReference, error) {
+	raw, err := readArchiveMember(archivePath, "manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	var items []dockerArchiveManifestItem
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, errors.Wrapf(err, "Error parsing manifest.json in %q", archivePath)
+	}
+
+	var sourceReferences []types.ImageReference
+	for _, item := range items {
+		for _, tag := range item.RepoTags {
+			ref, err := dockerArchive.Transport.ParseReference(fmt.Sprintf("%s:%s", archivePath, tag))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Cannot obtain a valid image reference for %q and tag %q", archivePath, tag)
+			}
+			sourceReferences = append(sourceReferences, ref)
+		}
+	}
+	return sourceReferences, nil
+}
+
+// taggedReference returns ref's tag and true, or ("", false) if ref is not tagged.
+func taggedReference(ref types.ImageReference) (string, bool) {
+	tagged, ok := ref.DockerReference().(reference.Tagged)
+	if !ok {
+		return "", false
+	}
+	return tagged.Tag(), true
+}
+
+// excludeTagsFilter drops refs whose tag matches any of excludePatterns.
+// Untagged refs are kept, since there is nothing to match against.
+func excludeTagsFilter(refs []types.ImageReference, excludePatterns []string, repoLogger *logrus.Entry) ([]types.ImageReference, error) {
+	if len(excludePatterns) == 0 {
+		return refs, nil
+	}
+	excludeRegexes := make([]*regexp.Regexp, 0, len(excludePatterns))
+	for _, pattern := range excludePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error parsing exclude-tags regex %q", pattern)
+		}
+		excludeRegexes = append(excludeRegexes, re)
+	}
+
+	var kept []types.ImageReference
+	for _, ref := range refs {
+		tag, isTagged := taggedReference(ref)
+		excluded := false
+		if isTagged {
+			for _, re := range excludeRegexes {
+				if re.MatchString(tag) {
+					excluded = true
+					break
+				}
+			}
+		}
+		if !excluded {
+			kept = append(kept, ref)
+		}
+	}
+	repoLogger.Infof("exclude-tags filter: kept %d/%d tags", len(kept), len(refs))
+	return kept, nil
+}
+
+// semverFilter drops refs whose tag is not a semantic version satisfying
+// constraintExpr (e.g. ">=1.20 <2.0"). Refs with non-semver tags are dropped,
+// since they cannot be compared against the constraint.
+func semverFilter(refs []types.ImageReference, constraintExpr string, repoLogger *logrus.Entry) ([]types.ImageReference, error) {
+	if constraintExpr == "" {
+		return refs, nil
+	}
+	constraint, err := semver.NewConstraint(constraintExpr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error parsing semver constraint %q", constraintExpr)
+	}
+
+	var kept []types.ImageReference
+	for _, ref := range refs {
+		tag, isTagged := taggedReference(ref)
+		if !isTagged {
+			continue
+		}
+		version, err := semver.NewVersion(tag)
+		if err != nil {
+			repoLogger.Debugf("Tag %q is not a semantic version, dropping: %v", tag, err)
+			continue
+		}
+		if constraint.Check(version) {
+			kept = append(kept, ref)
+		}
+	}
+	repoLogger.Infof("semver filter %q: kept %d/%d tags", constraintExpr, len(kept), len(refs))
+	return kept, nil
+}
+
+// lastNFilter keeps only the lastN most recently created refs, ordered by
+// each image's config "created" timestamp. Refs whose creation time cannot
+// be determined are kept regardless of lastN, since there is no sound way
+// to rank them.
+func lastNFilter(ctx context.Context, sys *types.SystemContext, refs []types.ImageReference, lastN int, repoLogger *logrus.Entry) []types.ImageReference {
+	if lastN <= 0 || len(refs) <= lastN {
+		return refs
+	}
+
+	type timedRef struct {
+		ref     types.ImageReference
+		created time.Time
+	}
+	var timed []timedRef
+	var undated []types.ImageReference
+	for _, ref := range refs {
+		created, ok := imageCreatedTime(ctx, ref, sys)
+		if !ok {
+			repoLogger.Warnf("Could not determine creation time of %s, keeping it regardless of last-n", transports.ImageName(ref))
+			undated = append(undated, ref)
+			continue
+		}
+		timed = append(timed, timedRef{ref, created})
+	}
+
+	sort.Slice(timed, func(i, j int) bool { return timed[i].created.After(timed[j].created) })
+	if len(timed) > lastN {
+		timed = timed[:lastN]
+	}
+
+	kept := make([]types.ImageReference, 0, len(timed)+len(undated))
+	for _, t := range timed {
+		kept = append(kept, t.ref)
+	}
+	kept = append(kept, undated...)
+	repoLogger.Infof("last-n filter: kept %d/%d tags", len(kept), len(refs))
+	return kept
+}
+
+// filterSourceReferences narrows refs down according to cfg's exclude-tags,
+// semver and last-n selectors, applied in that order.
+func filterSourceReferences(ctx context.Context, sys *types.SystemContext, refs []types.ImageReference, cfg registrySyncConfig, repoLogger *logrus.Entry) ([]types.ImageReference, error) {
+	refs, err := excludeTagsFilter(refs, cfg.ExcludeTags, repoLogger)
+	if err != nil {
+		return nil, err
+	}
+	refs, err = semverFilter(refs, cfg.Semver, repoLogger)
+	if err != nil {
+		return nil, err
+	}
+	refs = lastNFilter(ctx, sys, refs, cfg.LastN, repoLogger)
+	return refs, nil
+}
+
+// manifestListPlatforms mirrors the "manifests" entries of a docker schema2
+// manifest list or an OCI index; both formats use the same JSON shape for
+// the fields used here.
+type manifestListPlatforms struct {
+	Manifests []struct {
+		Digest   digest.Digest      `json:"digest"`
+		Platform imgspecv1.Platform `json:"platform"`
+	} `json:"manifests"`
+}
+
+// platformKey returns the "os/arch[/variant]" string identifying platform,
+// matching the syntax of the YAML "platforms" entry (e.g. "linux/arm64/v8").
+func platformKey(platform imgspecv1.Platform) string {
+	key := platform.OS + "/" + platform.Architecture
+	if platform.Variant != "" {
+		key += "/" + platform.Variant
+	}
+	return key
+}
+
+// manifestListPlatformDigests returns the digests of ref's manifest list
+// entries whose platform is in wantPlatforms, and true if ref is a manifest
+// list at all (false if it is a single-arch image, in which case platform
+// filtering does not apply).
+func manifestListPlatformDigests(ctx context.Context, ref types.ImageReference, sys *types.SystemContext, wantPlatforms []string) ([]digest.Digest, bool, error) {
+	src, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, false, err
+	}
+	defer src.Close()
+	blob, mimeType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if !manifest.MIMETypeIsMultiImage(mimeType) {
+		return nil, false, nil
+	}
+
+	var list manifestListPlatforms
+	if err := json.Unmarshal(blob, &list); err != nil {
+		return nil, false, errors.Wrapf(err, "Error parsing manifest list for %s", transports.ImageName(ref))
+	}
+
+	wanted := make(map[string]bool, len(wantPlatforms))
+	for _, p := range wantPlatforms {
+		wanted[p] = true
+	}
+	var digests []digest.Digest
+	for _, m := range list.Manifests {
+		if wanted[platformKey(m.Platform)] {
+			digests = append(digests, m.Digest)
+		}
+	}
+	return digests, true, nil
+}
+
 // imagesToCopyFromRegistry builds a list of repository descriptors from the images
 // in a registry configuration.
 // It returns a repository descriptors slice with as many elements as the images
 // found and any error encountered. Each element of the slice is a list of
 // image references, to be used as sync source.
-func imagesToCopyFromRegistry(registryName string, cfg registrySyncConfig, sourceCtx types.SystemContext) ([]repoDescriptor, error) {
+func imagesToCopyFromRegistry(ctx context.Context, registryName string, cfg registrySyncConfig, sourceCtx types.SystemContext, encryptLayers []int) ([]repoDescriptor, error) {
 	serverCtx := &sourceCtx
 	// override ctx with per-registryName options
 	serverCtx.DockerCertPath = cfg.CertDir
@@ -288,13 +656,23 @@ func imagesToCopyFromRegistry(registryName string, cfg registrySyncConfig, sourc
 	if cfg.Credentials != (types.DockerAuthConfig{}) {
 		serverCtx.DockerAuthConfig = &cfg.Credentials
 	}
+
+	_, encConfig, err := getEncryptConfig(cfg.EncryptionKeys, encryptLayers)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error parsing encryption-keys for registry %q", registryName)
+	}
+	decConfig, err := getDecryptConfig(cfg.DecryptionKeys)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error parsing decryption-keys for registry %q", registryName)
+	}
+
 	var repoDescList []repoDescriptor
 	for imageName, refs := range cfg.Images {
 		repoLogger := logrus.WithFields(logrus.Fields{
 			"repo":     imageName,
 			"registry": registryName,
 		})
-		repoRef, err := parseRepositoryReference(fmt.Sprintf("%s/%s", registryName, imageName))
+		repoRef, err := resolveImageName(serverCtx, registryName, imageName)
 		if err != nil {
 			repoLogger.Error("Error parsing repository name, skipping")
 			logrus.Error(err)
@@ -344,13 +722,21 @@ func imagesToCopyFromRegistry(registryName string, cfg registrySyncConfig, sourc
 			}
 		}
 
+		sourceReferences, err = filterSourceReferences(ctx, serverCtx, sourceReferences, cfg, repoLogger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error filtering tags for %q", imageName)
+		}
+
 		if len(sourceReferences) == 0 {
 			repoLogger.Warnf("No refs to sync found")
 			continue
 		}
 		repoDescList = append(repoDescList, repoDescriptor{
-			ImageRefs: sourceReferences,
-			Context:   serverCtx})
+			ImageRefs:     sourceReferences,
+			Context:       serverCtx,
+			EncryptConfig: encConfig,
+			DecryptConfig: decConfig,
+			Platforms:     cfg.Platforms})
 	}
 
 	for imageName, tagRegex := range cfg.ImagesByTagRegex {
@@ -358,7 +744,7 @@ func imagesToCopyFromRegistry(registryName string, cfg registrySyncConfig, sourc
 			"repo":     imageName,
 			"registry": registryName,
 		})
-		repoRef, err := parseRepositoryReference(fmt.Sprintf("%s/%s", registryName, imageName))
+		repoRef, err := resolveImageName(serverCtx, registryName, imageName)
 		if err != nil {
 			repoLogger.Error("Error parsing repository name, skipping")
 			logrus.Error(err)
@@ -398,13 +784,80 @@ func imagesToCopyFromRegistry(registryName string, cfg registrySyncConfig, sourc
 			}
 		}
 
+		sourceReferences, err = filterSourceReferences(ctx, serverCtx, sourceReferences, cfg, repoLogger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error filtering tags for %q", imageName)
+		}
+
 		if len(sourceReferences) == 0 {
 			repoLogger.Warnf("No refs to sync found")
 			continue
 		}
+		repoDescList = append(repoDescList, repoDescriptor{
+			ImageRefs:     sourceReferences,
+			Context:       serverCtx,
+			EncryptConfig: encConfig,
+			DecryptConfig: decConfig,
+			Platforms:     cfg.Platforms})
+	}
+
+	return repoDescList, nil
+}
+
+// imagesToCopyFromRegistriesConf turns a registries.conf file at path (or, if
+// path is empty, the system's default registries.conf) into a sync source:
+// every configured [[registry]] whose Prefix names a repository (rather than
+// just a bare registry host) is synced in full. Registries configured only
+// at the host level are skipped, since registries.conf has no notion of
+// "every repository in this registry" and skopeo has no registry catalog
+// crawler to discover one.
+func imagesToCopyFromRegistriesConf(path string, sourceCtx *types.SystemContext) ([]repoDescriptor, error) {
+	sysCtx := sourceCtx
+	if path != "" {
+		sysCtxCopy := *sourceCtx
+		sysCtxCopy.SystemRegistriesConfPath = path
+		sysCtx = &sysCtxCopy
+	}
+
+	registries, err := sysregistriesv2.GetRegistries(sysCtx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error parsing registries.conf")
+	}
+
+	var repoDescList []repoDescriptor
+	for _, registry := range registries {
+		regLogger := logrus.WithFields(logrus.Fields{"prefix": registry.Prefix})
+		prefix := registry.Prefix
+		if prefix == "" {
+			prefix = registry.Location
+		}
+		if !strings.Contains(prefix, "/") {
+			regLogger.Warn("registries.conf entry has no repository path, only a registry host; skipping since skopeo cannot enumerate a registry's full catalog")
+			continue
+		}
+		repoRef, err := parseRepositoryReference(prefix)
+		if err != nil {
+			regLogger.Error("Error parsing repository name, skipping")
+			logrus.Error(err)
+			continue
+		}
+
+		regLogger.Info("Processing repo")
+		sourceReferences, err := imagesToCopyFromRepo(sysCtx, repoRef)
+		if err != nil {
+			regLogger.Error("Error processing repo, skipping")
+			logrus.Error(err)
+			continue
+		}
+		if len(sourceReferences) == 0 {
+			regLogger.Warn("No refs to sync found")
+			continue
+		}
+
 		repoDescList = append(repoDescList, repoDescriptor{
 			ImageRefs: sourceReferences,
-			Context:   serverCtx})
+			Context:   sysCtx,
+		})
 	}
 
 	return repoDescList, nil
@@ -415,7 +868,7 @@ func imagesToCopyFromRegistry(registryName string, cfg registrySyncConfig, sourc
 // It returns a slice of repository descriptors, where each descriptor is a
 // list of tagged image references to be used as sync source, and any error
 // encountered.
-func imagesToCopy(source string, transport string, sourceCtx *types.SystemContext) ([]repoDescriptor, error) {
+func imagesToCopy(ctx context.Context, source string, transport string, sourceCtx *types.SystemContext, encryptLayers []int) ([]repoDescriptor, error) {
 	var descriptors []repoDescriptor
 
 	switch transport {
@@ -468,6 +921,63 @@ func imagesToCopy(source string, transport string, sourceCtx *types.SystemContex
 		}
 		descriptors = append(descriptors, desc)
 
+	case ociLayout.Transport.Name():
+		desc := repoDescriptor{
+			Context: sourceCtx,
+		}
+
+		if _, err := os.Stat(source); err != nil {
+			return descriptors, errors.Wrap(err, "Invalid source OCI layout directory specified")
+		}
+		desc.DirBasePath = source
+		var err error
+		desc.ImageRefs, err = imagesToCopyFromOCILayout(source)
+		if err != nil {
+			return descriptors, err
+		}
+		if len(desc.ImageRefs) == 0 {
+			return descriptors, errors.Errorf("No images to sync found in %q", source)
+		}
+		descriptors = append(descriptors, desc)
+
+	case ociArchive.Transport.Name():
+		desc := repoDescriptor{
+			Context: sourceCtx,
+		}
+
+		if _, err := os.Stat(source); err != nil {
+			return descriptors, errors.Wrap(err, "Invalid source OCI archive specified")
+		}
+		desc.DirBasePath = source
+		var err error
+		desc.ImageRefs, err = imagesToCopyFromOCIArchive(source)
+		if err != nil {
+			return descriptors, err
+		}
+		if len(desc.ImageRefs) == 0 {
+			return descriptors, errors.Errorf("No images to sync found in %q", source)
+		}
+		descriptors = append(descriptors, desc)
+
+	case dockerArchive.Transport.Name():
+		desc := repoDescriptor{
+			Context: sourceCtx,
+		}
+
+		if _, err := os.Stat(source); err != nil {
+			return descriptors, errors.Wrap(err, "Invalid source docker archive specified")
+		}
+		desc.DirBasePath = source
+		var err error
+		desc.ImageRefs, err = imagesToCopyFromDockerArchive(source)
+		if err != nil {
+			return descriptors, err
+		}
+		if len(desc.ImageRefs) == 0 {
+			return descriptors, errors.Errorf("No images to sync found in %q", source)
+		}
+		descriptors = append(descriptors, desc)
+
 	case "yaml":
 		cfg, err := newSourceConfig(source)
 		if err != nil {
@@ -481,29 +991,150 @@ func imagesToCopy(source string, transport string, sourceCtx *types.SystemContex
 				continue
 			}
 
-			descs, err := imagesToCopyFromRegistry(registryName, registryConfig, *sourceCtx)
+			descs, err := imagesToCopyFromRegistry(ctx, registryName, registryConfig, *sourceCtx, encryptLayers)
 			if err != nil {
 				return descriptors, errors.Wrapf(err, "Failed to retrieve list of images from registry %q", registryName)
 			}
 			descriptors = append(descriptors, descs...)
 		}
+
+	case "registries-conf":
+		descs, err := imagesToCopyFromRegistriesConf(source, sourceCtx)
+		if err != nil {
+			return descriptors, err
+		}
+		descriptors = append(descriptors, descs...)
 	}
 
 	return descriptors, nil
 }
 
+// getEncryptConfig builds an encconfig.EncryptConfig and the effective layer
+// selection out of encryptionKeys, or returns a nil config if encryptionKeys
+// is empty.
+func getEncryptConfig(encryptionKeys []string, encryptLayers []int) (*[]int, *encconfig.EncryptConfig, error) {
+	if len(encryptionKeys) == 0 {
+		return nil, nil, nil
+	}
+	encLayers := encryptLayers
+	ecc, err := helpers.CreateCryptoConfig(encryptionKeys, []string{})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Invalid encryption keys")
+	}
+	cc := encconfig.CombineCryptoConfigs([]encconfig.CryptoConfig{ecc})
+	return &encLayers, cc.EncryptConfig, nil
+}
+
+// getDecryptConfig builds an encconfig.DecryptConfig out of decryptionKeys,
+// or returns a nil config if decryptionKeys is empty.
+func getDecryptConfig(decryptionKeys []string) (*encconfig.DecryptConfig, error) {
+	if len(decryptionKeys) == 0 {
+		return nil, nil
+	}
+	dcc, err := helpers.CreateCryptoConfig([]string{}, decryptionKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid decryption keys")
+	}
+	cc := encconfig.CombineCryptoConfigs([]encconfig.CryptoConfig{dcc})
+	return cc.DecryptConfig, nil
+}
+
+// Pull policies for syncOptions.pullPolicy, controlling whether an image
+// already present at the destination can be skipped.
+const (
+	pullPolicyAlways  = "always"  // Always copy, regardless of what is already at the destination
+	pullPolicyMissing = "missing" // Skip if any image is already present at the destination
+	pullPolicyNewer   = "newer"   // Skip if the destination already has an image at least as new as the source
+)
+
+// validPullPolicies lists the valid syncOptions.pullPolicy values, in the
+// order they should be presented in usage text.
+var validPullPolicies = []string{pullPolicyAlways, pullPolicyMissing, pullPolicyNewer}
+
+// manifestDigest returns the manifest digest of ref and true, or ("", false)
+// if ref could not be read (e.g. because nothing exists there yet). Errors
+// opening or reading ref are deliberately not propagated: the caller treats
+// "can't tell" the same as "nothing there", so sync conservatively falls
+// back to copying instead of silently skipping.
+func manifestDigest(ctx context.Context, ref types.ImageReference, sys *types.SystemContext) (digest.Digest, bool) {
+	src, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return "", false
+	}
+	defer src.Close()
+	manifestBlob, _, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return "", false
+	}
+	d, err := manifest.Digest(manifestBlob)
+	if err != nil {
+		return "", false
+	}
+	return d, true
+}
+
+// imageCreatedTime returns the image's "created" timestamp and true, or
+// (zero, false) if it could not be determined (this does not work for
+// manifest lists, which have no single creation time).
+func imageCreatedTime(ctx context.Context, ref types.ImageReference, sys *types.SystemContext) (time.Time, bool) {
+	src, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer src.Close()
+	img, err := image.FromSource(ctx, sys, src)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer img.Close()
+	inspect, err := img.Inspect(ctx)
+	if err != nil || inspect.Created == nil {
+		return time.Time{}, false
+	}
+	return *inspect.Created, true
+}
+
+// shouldSkipCopy decides, according to policy, whether copying job.ref to
+// job.destRef can be skipped because an image is already present at the
+// destination.
+func shouldSkipCopy(ctx context.Context, policy string, job syncCopyJob, destCtx *types.SystemContext) bool {
+	if policy != pullPolicyMissing && policy != pullPolicyNewer {
+		return false
+	}
+
+	destDigest, destExists := manifestDigest(ctx, job.destRef, destCtx)
+	if !destExists {
+		return false
+	}
+	if policy == pullPolicyMissing {
+		return true
+	}
+
+	srcDigest, srcExists := manifestDigest(ctx, job.ref, job.sourceCtx)
+	if !srcExists {
+		return false
+	}
+	if srcDigest == destDigest {
+		return true
+	}
+
+	srcCreated, ok := imageCreatedTime(ctx, job.ref, job.sourceCtx)
+	if !ok {
+		return false
+	}
+	destCreated, ok := imageCreatedTime(ctx, job.destRef, destCtx)
+	if !ok {
+		return false
+	}
+	return !srcCreated.After(destCreated)
+}
+
 func (opts *syncOptions) run(args []string, stdout io.Writer) error {
 	if len(args) != 2 {
 		return errorShouldDisplayUsage{errors.New("Exactly two arguments expected")}
 	}
 	opts.deprecatedTLSVerify.warnIfUsed([]string{"--src-tls-verify", "--dest-tls-verify"})
 
-	policyContext, err := opts.global.getPolicyContext()
-	if err != nil {
-		return errors.Wrapf(err, "Error loading trust policy")
-	}
-	defer policyContext.Destroy()
-
 	// validate source and destination options
 	contains := func(val string, list []string) (_ bool) {
 		for _, l := range list {
@@ -517,14 +1148,14 @@ func (opts *syncOptions) run(args []string, stdout io.Writer) error {
 	if len(opts.source) == 0 {
 		return errors.New("A source transport must be specified")
 	}
-	if !contains(opts.source, []string{docker.Transport.Name(), directory.Transport.Name(), "yaml"}) {
+	if !contains(opts.source, []string{docker.Transport.Name(), directory.Transport.Name(), ociLayout.Transport.Name(), ociArchive.Transport.Name(), dockerArchive.Transport.Name(), "yaml", "registries-conf"}) {
 		return errors.Errorf("%q is not a valid source transport", opts.source)
 	}
 
 	if len(opts.destination) == 0 {
 		return errors.New("A destination transport must be specified")
 	}
-	if !contains(opts.destination, []string{docker.Transport.Name(), directory.Transport.Name()}) {
+	if !contains(opts.destination, []string{docker.Transport.Name(), directory.Transport.Name(), ociLayout.Transport.Name(), ociArchive.Transport.Name(), dockerArchive.Transport.Name()}) {
 		return errors.Errorf("%q is not a valid destination transport", opts.destination)
 	}
 
@@ -532,11 +1163,41 @@ func (opts *syncOptions) run(args []string, stdout io.Writer) error {
 		return errors.New("sync from 'dir' to 'dir' not implemented, consider using rsync instead")
 	}
 
+	workers := opts.workers
+	if contains(opts.destination, []string{ociLayout.Transport.Name(), ociArchive.Transport.Name(), dockerArchive.Transport.Name()}) && workers > 1 {
+		// Every job's destPath for these transports is the same literal
+		// archive path, differing only by the ":tag" suffix. Nothing
+		// coordinates concurrent writers to a single archive, so force
+		// serial copying rather than risk a corrupted archive.
+		logrus.Warnf("Destination is %q; forcing --workers=1, concurrent writes to a single archive are not supported", opts.destination)
+		workers = 1
+	}
+
+	if opts.pullPolicy == "" {
+		opts.pullPolicy = pullPolicyAlways
+	}
+	if !contains(opts.pullPolicy, validPullPolicies) {
+		return errors.Errorf("%q is not a valid pull policy", opts.pullPolicy)
+	}
+
 	imageListSelection := copy.CopySystemImage
 	if opts.all {
 		imageListSelection = copy.CopyAllImages
 	}
 
+	if len(opts.encryptLayer) > 0 && len(opts.encryptionKeys) == 0 {
+		return errorShouldDisplayUsage{errors.New("--encrypt-layer can only be used with --encryption-key")}
+	}
+
+	encLayers, encConfig, err := getEncryptConfig(opts.encryptionKeys, opts.encryptLayer)
+	if err != nil {
+		return err
+	}
+	decConfig, err := getDecryptConfig(opts.decryptionKeys)
+	if err != nil {
+		return err
+	}
+
 	sourceCtx, err := opts.srcImage.newSystemContext()
 	if err != nil {
 		return err
@@ -556,7 +1217,7 @@ func (opts *syncOptions) run(args []string, stdout io.Writer) error {
 	sourceArg := args[0]
 	var srcRepoList []repoDescriptor
 	if err = retry.RetryIfNecessary(ctx, func() error {
-		srcRepoList, err = imagesToCopy(sourceArg, opts.source, sourceCtx)
+		srcRepoList, err = imagesToCopy(ctx, sourceArg, opts.source, sourceCtx, opts.encryptLayer)
 		return err
 	}, opts.retryOpts); err != nil {
 		return err
@@ -568,20 +1229,21 @@ func (opts *syncOptions) run(args []string, stdout io.Writer) error {
 		return err
 	}
 
-	imagesNumber := 0
 	options := copy.Options{
 		RemoveSignatures:                      opts.removeSignatures,
 		SignBy:                                opts.signByFingerprint,
-		ReportWriter:                          os.Stdout,
 		DestinationCtx:                        destinationCtx,
 		ImageListSelection:                    imageListSelection,
 		OptimizeDestinationImageAlreadyExists: true,
 		ForceManifestMIMEType:                 manifestType,
+		OciEncryptLayers:                      encLayers,
+		OciEncryptConfig:                      encConfig,
+		OciDecryptConfig:                      decConfig,
 	}
 
+	var jobs []syncCopyJob
 	for _, srcRepo := range srcRepoList {
-		options.SourceCtx = srcRepo.Context
-		for counter, ref := range srcRepo.ImageRefs {
+		for _, ref := range srcRepo.ImageRefs {
 			var destSuffix string
 			switch ref.Transport() {
 			case docker.Transport:
@@ -594,32 +1256,203 @@ func (opts *syncOptions) run(args []string, stdout io.Writer) error {
 					// if source is a full path to an image, have destPath scoped to repo:tag
 					destSuffix = path.Base(srcRepo.DirBasePath)
 				}
+			case ociLayout.Transport, ociArchive.Transport, dockerArchive.Transport:
+				// oci / oci-archive / docker-archive -> docker or dir: scope by base name and tag,
+				// since these sources may hold more than one tagged image per path.
+				destSuffix = path.Base(srcRepo.DirBasePath)
+				if parts := strings.SplitN(ref.StringWithinTransport(), ":", 2); len(parts) == 2 && parts[1] != "" {
+					destSuffix += ":" + parts[1]
+				}
 			}
 
 			if !opts.scoped {
 				destSuffix = path.Base(destSuffix)
 			}
 
-			destRef, err := destinationReference(path.Join(destination, destSuffix), opts.destination)
+			var destPath string
+			switch opts.destination {
+			case ociLayout.Transport.Name(), ociArchive.Transport.Name(), dockerArchive.Transport.Name():
+				// These transports address a single file (or directory, for
+				// oci) given on the command line; every image in the sync
+				// goes into that same literal path, distinguished only by
+				// the ":tag" suffix understood by their ParseReference.
+				tag := "latest"
+				if t, ok := taggedReference(ref); ok {
+					tag = t
+				}
+				destPath = fmt.Sprintf("%s:%s", destination, tag)
+			default:
+				destPath = path.Join(destination, destSuffix)
+			}
+
+			destRef, err := destinationReference(destPath, opts.destination)
 			if err != nil {
 				return err
 			}
 
-			logrus.WithFields(logrus.Fields{
-				"from": transports.ImageName(ref),
-				"to":   transports.ImageName(destRef),
-			}).Infof("Copying image ref %d/%d", counter+1, len(srcRepo.ImageRefs))
+			jobs = append(jobs, syncCopyJob{
+				ref:           ref,
+				sourceCtx:     srcRepo.Context,
+				destRef:       destRef,
+				encryptConfig: srcRepo.EncryptConfig,
+				decryptConfig: srcRepo.DecryptConfig,
+				platforms:     srcRepo.Platforms,
+				all:           opts.all,
+			})
+		}
+	}
+
+	return runSyncCopyJobs(ctx, opts.global, jobs, options, opts.retryOpts, workers, opts.pullPolicy, len(srcRepoList))
+}
 
-			if err = retry.RetryIfNecessary(ctx, func() error {
-				_, err = copy.Image(ctx, policyContext, destRef, ref, &options)
+// syncCopyJob is a single image copy to be performed by runSyncCopyJobs.
+type syncCopyJob struct {
+	ref           types.ImageReference     // Source image reference
+	sourceCtx     *types.SystemContext     // SystemContext of the repository ref was found in
+	destRef       types.ImageReference     // Destination image reference
+	encryptConfig *encconfig.EncryptConfig // Per-registry override for optionsTemplate.OciEncryptConfig, if set
+	decryptConfig *encconfig.DecryptConfig // Per-registry override for optionsTemplate.OciDecryptConfig, if set
+	platforms     []string                 // Manifest list platforms to keep, from the YAML "platforms" entry, if ref is a manifest list
+	all           bool                     // Whether --all was given; platforms only filters a manifest list if this is set
+}
+
+// syncedReportWriter serializes writes from concurrent copy.Image calls so
+// that per-copy progress lines are not interleaved.
+type syncedReportWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncedReportWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// runSyncCopyJobs copies jobs using up to workers concurrent goroutines,
+// sharing optionsTemplate (except for SourceCtx, ReportWriter, the per-registry
+// encryption and decryption configs, and the platform-based ImageListSelection
+// and Instances, which are set per job). A failure copying one job does not prevent the others from
+// running; all failures are returned together. Cancelling ctx stops
+// dispatching further jobs and lets in-flight copies fail on their own.
+//
+// Each worker goroutine builds its own *signature.PolicyContext from global:
+// PolicyContext is not safe for concurrent use by multiple in-flight copies,
+// so one shared instance cannot be handed to every worker.
+func runSyncCopyJobs(ctx context.Context, global *globalOptions, jobs []syncCopyJob, optionsTemplate copy.Options, retryOpts *retry.RetryOptions, workers int, pullPolicy string, sourcesNumber int) error {
+	if workers < 1 {
+		workers = 1
+	}
+	reportWriter := &syncedReportWriter{w: os.Stdout}
+
+	jobCh := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var copyErrors *multierror.Error
+	var synced, skipped int32
+	total := len(jobs)
+
+	worker := func() error {
+		policyContext, err := global.getPolicyContext()
+		if err != nil {
+			return errors.Wrapf(err, "Error loading trust policy")
+		}
+		defer policyContext.Destroy()
+
+		for i := range jobCh {
+			job := jobs[i]
+
+			if shouldSkipCopy(ctx, pullPolicy, job, optionsTemplate.DestinationCtx) {
+				atomic.AddInt32(&skipped, 1)
+				logrus.WithFields(logrus.Fields{
+					"from": transports.ImageName(job.ref),
+					"to":   transports.ImageName(job.destRef),
+				}).Infof("Skipping, already up to date (policy %q)", pullPolicy)
+				continue
+			}
+
+			options := optionsTemplate
+			options.SourceCtx = job.sourceCtx
+			options.ReportWriter = reportWriter
+			if job.encryptConfig != nil {
+				options.OciEncryptConfig = job.encryptConfig
+			}
+			if job.decryptConfig != nil {
+				options.OciDecryptConfig = job.decryptConfig
+			}
+			if job.all && len(job.platforms) > 0 {
+				digests, isList, err := manifestListPlatformDigests(ctx, job.ref, job.sourceCtx, job.platforms)
+				if err != nil {
+					mu.Lock()
+					copyErrors = multierror.Append(copyErrors, errors.Wrapf(err, "Error reading manifest list for %q", transports.ImageName(job.ref)))
+					mu.Unlock()
+					continue
+				}
+				if isList {
+					if len(digests) == 0 {
+						atomic.AddInt32(&skipped, 1)
+						logrus.WithFields(logrus.Fields{
+							"from": transports.ImageName(job.ref),
+						}).Infof("Skipping, no instance matches platforms %v", job.platforms)
+						continue
+					}
+					options.ImageListSelection = copy.CopySpecificImages
+					options.Instances = digests
+				}
+			}
+
+			err := retry.RetryIfNecessary(ctx, func() error {
+				_, err := copy.Image(ctx, policyContext, job.destRef, job.ref, &options)
 				return err
-			}, opts.retryOpts); err != nil {
-				return errors.Wrapf(err, "Error copying ref %q", transports.ImageName(ref))
+			}, retryOpts)
+
+			mu.Lock()
+			if err != nil {
+				copyErrors = multierror.Append(copyErrors, errors.Wrapf(err, "Error copying ref %q", transports.ImageName(job.ref)))
+			} else {
+				atomic.AddInt32(&synced, 1)
+			}
+			done := int(atomic.LoadInt32(&synced)) + int(atomic.LoadInt32(&skipped))
+			failed := 0
+			if copyErrors != nil {
+				failed = len(copyErrors.Errors)
 			}
-			imagesNumber++
+			mu.Unlock()
+
+			logrus.WithFields(logrus.Fields{
+				"from": transports.ImageName(job.ref),
+				"to":   transports.ImageName(job.destRef),
+			}).Infof("Copied %d/%d images (%d failed)", done+failed, total, failed)
+		}
+		return nil
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := worker(); err != nil {
+				mu.Lock()
+				copyErrors = multierror.Append(copyErrors, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for i := range jobs {
+		select {
+		case jobCh <- i:
+		case <-ctx.Done():
+			break dispatch
 		}
 	}
+	close(jobCh)
+	wg.Wait()
 
-	logrus.Infof("Synced %d images from %d sources", imagesNumber, len(srcRepoList))
+	if copyErrors != nil {
+		return copyErrors.ErrorOrNil()
+	}
+	logrus.Infof("Synced %d images from %d sources, skipped %d", int(synced), sourcesNumber, int(skipped))
 	return nil
 }
@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/containers/image/v5/signature"
 	"gopkg.in/check.v1"
@@ -52,6 +55,43 @@ func (s *SigningSuite) SetUpSuite(c *check.C) {
 	c.Assert(err, check.IsNil)
 }
 
+// newGPGHome creates a fresh GPG home directory with a single RSA key,
+// passing extra batch-mode key-gen parameters (e.g. "Expire-Date:
+// seconds=2") verbatim. It returns the directory and the new key's
+// fingerprint; the caller is responsible for removing the directory.
+func newGPGHome(c *check.C, extraKeyParams string) (string, string) {
+	gpgHome, err := ioutil.TempDir("", "skopeo-gpg")
+	c.Assert(err, check.IsNil)
+
+	runCommandWithInput(c, fmt.Sprintf("Key-Type: RSA\nName-Real: Testing user\n%%no-protection\n%s%%commit\n", extraKeyParams),
+		gpgBinary, "--homedir", gpgHome, "--batch", "--gen-key")
+
+	lines, err := exec.Command(gpgBinary, "--homedir", gpgHome, "--with-colons", "--no-permission-warning", "--fingerprint").Output()
+	c.Assert(err, check.IsNil)
+	fingerprint, err := findFingerprint(lines)
+	c.Assert(err, check.IsNil)
+
+	return gpgHome, fingerprint
+}
+
+// withGNUPGHome temporarily points GNUPGHOME at gpgHome for the duration of fn,
+// restoring the suite's own gpgHome afterwards.
+func (s *SigningSuite) withGNUPGHome(gpgHome string, fn func()) {
+	os.Setenv("GNUPGHOME", gpgHome)
+	defer os.Setenv("GNUPGHOME", s.gpgHome)
+	fn()
+}
+
+// runVerifyExpectFailure runs "skopeo standalone-verify args..." and asserts
+// that it fails, returning the combined stdout+stderr for pattern matching
+// against the reported error message.
+func runVerifyExpectFailure(c *check.C, args ...string) string {
+	fullArgs := append([]string{"standalone-verify"}, args...)
+	out, err := exec.Command(skopeoBinary, fullArgs...).CombinedOutput()
+	c.Assert(err, check.NotNil, check.Commentf("Expected standalone-verify to fail, got: %s", out))
+	return string(out)
+}
+
 func (s *SigningSuite) TearDownSuite(c *check.C) {
 	if s.gpgHome != "" {
 		err := os.RemoveAll(s.gpgHome)
@@ -83,3 +123,288 @@ func (s *SigningSuite) TestSignVerifySmoke(c *check.C) {
 	assertSkopeoSucceeds(c, expected, "standalone-verify", manifestPath,
 		dockerReference, s.fingerprint, sigOutput.Name())
 }
+
+// TestSignVerifySignIdentity verifies that --sign-identity lets the caller
+// bind a signature to a reference other than the one the manifest is signed
+// against, and that verification against an unrelated reference is rejected.
+func (s *SigningSuite) TestSignVerifySignIdentity(c *check.C) {
+	mech, _, err := signature.NewEphemeralGPGSigningMechanism([]byte{})
+	c.Assert(err, check.IsNil)
+	defer mech.Close()
+	if err := mech.SupportsSigning(); err != nil {
+		c.Skip(fmt.Sprintf("Signing not supported: %v", err))
+	}
+
+	manifestPath := "fixtures/image.manifest.json"
+	pushReference := "push.example.com/foo"
+	pullReference := "pull.example.com/foo"
+
+	sigOutput, err := ioutil.TempFile("", "sig")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(sigOutput.Name())
+
+	// Without --sign-identity, the signature is bound to pushReference and
+	// does not verify against pullReference.
+	assertSkopeoSucceeds(c, "^$", "standalone-sign", "-o", sigOutput.Name(),
+		manifestPath, pushReference, s.fingerprint)
+	assertSkopeoFails(c, ".*", "standalone-verify", manifestPath,
+		pullReference, s.fingerprint, sigOutput.Name())
+
+	// With --sign-identity pullReference, the same manifest verifies
+	// against pullReference even though it was pushed as pushReference.
+	assertSkopeoSucceeds(c, "^$", "standalone-sign", "-o", sigOutput.Name(),
+		"--sign-identity", pullReference, manifestPath, pushReference, s.fingerprint)
+	expected := fmt.Sprintf("^Signature verified, digest %s\n$", TestImageManifestDigest)
+	assertSkopeoSucceeds(c, expected, "standalone-verify", manifestPath,
+		pullReference, s.fingerprint, sigOutput.Name())
+}
+
+// TestSignVerifyPassphraseProtectedKey verifies that a passphrase-protected
+// signing key can only be used with --passphrase-file supplying the
+// correct passphrase.
+func (s *SigningSuite) TestSignVerifyPassphraseProtectedKey(c *check.C) {
+	const passphrase = "topsecret"
+
+	protectedGPGHome, err := ioutil.TempDir("", "skopeo-gpg-protected")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(protectedGPGHome)
+
+	os.Setenv("GNUPGHOME", protectedGPGHome)
+	defer os.Setenv("GNUPGHOME", s.gpgHome)
+
+	runCommandWithInput(c, fmt.Sprintf("Key-Type: RSA\nName-Real: Protected Testing user\nPassphrase: %s\n%%commit\n", passphrase),
+		gpgBinary, "--homedir", protectedGPGHome, "--batch", "--gen-key")
+
+	lines, err := exec.Command(gpgBinary, "--homedir", protectedGPGHome, "--with-colons", "--no-permission-warning", "--fingerprint").Output()
+	c.Assert(err, check.IsNil)
+	fingerprint, err := findFingerprint(lines)
+	c.Assert(err, check.IsNil)
+
+	mech, _, err := signature.NewEphemeralGPGSigningMechanism([]byte{})
+	c.Assert(err, check.IsNil)
+	defer mech.Close()
+	if err := mech.SupportsSigning(); err != nil {
+		c.Skip(fmt.Sprintf("Signing not supported: %v", err))
+	}
+
+	manifestPath := "fixtures/image.manifest.json"
+	dockerReference := "testing/smoketest"
+
+	sigOutput, err := ioutil.TempFile("", "sig")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(sigOutput.Name())
+
+	// Without --passphrase-file, signing a passphrase-protected key fails.
+	assertSkopeoFails(c, ".*", "standalone-sign", "-o", sigOutput.Name(),
+		manifestPath, dockerReference, fingerprint)
+
+	passphraseFile, err := ioutil.TempFile("", "passphrase")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(passphraseFile.Name())
+	_, err = passphraseFile.WriteString(passphrase)
+	c.Assert(err, check.IsNil)
+	c.Assert(passphraseFile.Close(), check.IsNil)
+
+	assertSkopeoSucceeds(c, "^$", "standalone-sign", "-o", sigOutput.Name(),
+		"--passphrase-file", passphraseFile.Name(), manifestPath, dockerReference, fingerprint)
+
+	expected := fmt.Sprintf("^Signature verified, digest %s\n$", TestImageManifestDigest)
+	assertSkopeoSucceeds(c, expected, "standalone-verify", manifestPath,
+		dockerReference, fingerprint, sigOutput.Name())
+}
+
+// writeFakeSignerScript writes an executable shell script wrapping gpgHome's
+// keyring that behaves like a remote "exec:" signer: it detached-signs
+// stdin with the key identity given as its first argument and writes the
+// signature to stdout.
+func writeFakeSignerScript(c *check.C, gpgHome string) string {
+	script, err := ioutil.TempFile("", "fake-signer")
+	c.Assert(err, check.IsNil)
+	_, err = fmt.Fprintf(script, "#!/bin/sh\nexec %s --homedir %s --batch --pinentry-mode loopback --detach-sign --local-user \"$1\"\n", gpgBinary, gpgHome)
+	c.Assert(err, check.IsNil)
+	c.Assert(script.Close(), check.IsNil)
+	c.Assert(os.Chmod(script.Name(), 0755), check.IsNil)
+	return script.Name()
+}
+
+// TestSignVerifyRemoteExecSigner verifies end-to-end signing through an
+// "exec:" remote signer wrapping the suite's GPG key.
+func (s *SigningSuite) TestSignVerifyRemoteExecSigner(c *check.C) {
+	mech, _, err := signature.NewEphemeralGPGSigningMechanism([]byte{})
+	c.Assert(err, check.IsNil)
+	defer mech.Close()
+	if err := mech.SupportsSigning(); err != nil {
+		c.Skip(fmt.Sprintf("Signing not supported: %v", err))
+	}
+
+	signerPath := writeFakeSignerScript(c, s.gpgHome)
+	defer os.Remove(signerPath)
+
+	manifestPath := "fixtures/image.manifest.json"
+	dockerReference := "testing/smoketest"
+
+	sigOutput, err := ioutil.TempFile("", "sig")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(sigOutput.Name())
+
+	assertSkopeoSucceeds(c, "^$", "standalone-sign", "-o", sigOutput.Name(),
+		"--sign-by-remote", "exec:"+signerPath,
+		manifestPath, dockerReference, s.fingerprint)
+
+	expected := fmt.Sprintf("^Signature verified, digest %s\n$", TestImageManifestDigest)
+	assertSkopeoSucceeds(c, expected, "standalone-verify", manifestPath,
+		dockerReference, s.fingerprint, sigOutput.Name())
+}
+
+// TestSignVerifyRemoteHTTPSigner verifies end-to-end signing through an
+// http(s):// remote signer wrapping the suite's GPG key.
+func (s *SigningSuite) TestSignVerifyRemoteHTTPSigner(c *check.C) {
+	mech, _, err := signature.NewEphemeralGPGSigningMechanism([]byte{})
+	c.Assert(err, check.IsNil)
+	defer mech.Close()
+	if err := mech.SupportsSigning(); err != nil {
+		c.Skip(fmt.Sprintf("Signing not supported: %v", err))
+	}
+
+	signerPath := writeFakeSignerScript(c, s.gpgHome)
+	defer os.Remove(signerPath)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyIdentity := r.Header.Get("X-Signing-Key-Identity")
+		cmd := exec.Command(signerPath, keyIdentity)
+		cmd.Stdin = r.Body
+		out, err := cmd.Output()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(out)
+	}))
+	defer server.Close()
+
+	manifestPath := "fixtures/image.manifest.json"
+	dockerReference := "testing/smoketest"
+
+	sigOutput, err := ioutil.TempFile("", "sig")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(sigOutput.Name())
+
+	assertSkopeoSucceeds(c, "^$", "standalone-sign", "-o", sigOutput.Name(),
+		"--sign-by-remote", server.URL,
+		manifestPath, dockerReference, s.fingerprint)
+
+	expected := fmt.Sprintf("^Signature verified, digest %s\n$", TestImageManifestDigest)
+	assertSkopeoSucceeds(c, expected, "standalone-verify", manifestPath,
+		dockerReference, s.fingerprint, sigOutput.Name())
+}
+
+// TestSignVerifyExpiredSignature verifies that a signature made with a key
+// whose expiry has since passed is rejected with a clear error.
+func (s *SigningSuite) TestSignVerifyExpiredSignature(c *check.C) {
+	mech, _, err := signature.NewEphemeralGPGSigningMechanism([]byte{})
+	c.Assert(err, check.IsNil)
+	defer mech.Close()
+	if err := mech.SupportsSigning(); err != nil {
+		c.Skip(fmt.Sprintf("Signing not supported: %v", err))
+	}
+
+	expiringGPGHome, fingerprint := newGPGHome(c, "Expire-Date: seconds=2\n")
+	defer os.RemoveAll(expiringGPGHome)
+
+	manifestPath := "fixtures/image.manifest.json"
+	dockerReference := "testing/smoketest"
+
+	sigOutput, err := ioutil.TempFile("", "sig")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(sigOutput.Name())
+
+	s.withGNUPGHome(expiringGPGHome, func() {
+		assertSkopeoSucceeds(c, "^$", "standalone-sign", "-o", sigOutput.Name(),
+			manifestPath, dockerReference, fingerprint)
+	})
+
+	time.Sleep(3 * time.Second)
+
+	var out string
+	s.withGNUPGHome(expiringGPGHome, func() {
+		out = runVerifyExpectFailure(c, manifestPath, dockerReference, fingerprint, sigOutput.Name())
+	})
+	c.Assert(strings.Contains(strings.ToLower(out), "expired"), check.Equals, true,
+		check.Commentf("Expected an expiry-related error, got: %s", out))
+}
+
+// TestSignVerifyUnknownKey verifies that verifying a signature against a
+// keyring that does not contain the signing key's fingerprint fails with an
+// error naming the missing key.
+func (s *SigningSuite) TestSignVerifyUnknownKey(c *check.C) {
+	mech, _, err := signature.NewEphemeralGPGSigningMechanism([]byte{})
+	c.Assert(err, check.IsNil)
+	defer mech.Close()
+	if err := mech.SupportsSigning(); err != nil {
+		c.Skip(fmt.Sprintf("Signing not supported: %v", err))
+	}
+
+	manifestPath := "fixtures/image.manifest.json"
+	dockerReference := "testing/smoketest"
+
+	sigOutput, err := ioutil.TempFile("", "sig")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(sigOutput.Name())
+	assertSkopeoSucceeds(c, "^$", "standalone-sign", "-o", sigOutput.Name(),
+		manifestPath, dockerReference, s.fingerprint)
+
+	emptyGPGHome, err := ioutil.TempDir("", "skopeo-gpg-empty")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(emptyGPGHome)
+
+	var out string
+	s.withGNUPGHome(emptyGPGHome, func() {
+		out = runVerifyExpectFailure(c, manifestPath, dockerReference, s.fingerprint, sigOutput.Name())
+	})
+	c.Assert(strings.Contains(out, s.fingerprint), check.Equals, true,
+		check.Commentf("Expected the error to name the missing key %s, got: %s", s.fingerprint, out))
+}
+
+// buildV3SignaturePacket returns a syntactically valid (RFC 4880 §5.2.2),
+// but cryptographically meaningless, legacy version-3 OpenPGP signature
+// packet: an old-format packet header (tag 2) wrapping a v3 signature body
+// with a zeroed key ID and a one-byte bogus MPI signature value.
+//
+// Modern GnuPG (this suite's only available backend, GnuPG 2.2+) dropped
+// --force-v3-sigs and cannot produce a real v3 signature to exercise this
+// against, so there is no way to get a cryptographically valid fixture out
+// of the tools available in this environment; what this buys instead is
+// confidence that a would-be v3 signature is rejected cleanly rather than
+// panicking or hanging, which is the only generally testable property of
+// "legacy v3 packet handling" without a patched GPG.
+func buildV3SignaturePacket() []byte {
+	const sigType = 0x00 // binary document
+	const pubKeyAlgoRSA = 1
+	const hashAlgoSHA1 = 2
+
+	body := []byte{3 /* version */, 5 /* hashed-material length, fixed at 5 for v3 */, sigType}
+	body = append(body, 0, 0, 0, 0)                  // 4-byte creation time
+	body = append(body, 0, 0, 0, 0, 0, 0, 0, 0)      // 8-byte key ID (zeroed, unknown)
+	body = append(body, pubKeyAlgoRSA, hashAlgoSHA1) // pubkey/hash algorithm
+	body = append(body, 0, 0)                        // left 16 bits of signed hash
+	body = append(body, 0, 8, 0)                     // MPI: 8-bit bogus signature value
+
+	header := []byte{0x88, byte(len(body))} // old-format packet, tag 2, 1-byte length
+	return append(header, body...)
+}
+
+// TestSignVerifyV3Packet verifies that a legacy version-3 OpenPGP signature
+// packet is rejected with a clean error rather than causing a crash or hang.
+func (s *SigningSuite) TestSignVerifyV3Packet(c *check.C) {
+	sigFile, err := ioutil.TempFile("", "v3sig")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(sigFile.Name())
+	_, err = sigFile.Write(buildV3SignaturePacket())
+	c.Assert(err, check.IsNil)
+	c.Assert(sigFile.Close(), check.IsNil)
+
+	manifestPath := "fixtures/image.manifest.json"
+	dockerReference := "testing/smoketest"
+	out := runVerifyExpectFailure(c, manifestPath, dockerReference, s.fingerprint, sigFile.Name())
+	c.Logf("v3 signature packet rejected cleanly: %s", out)
+}
@@ -0,0 +1,55 @@
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/image/v5/types"
+	"github.com/pkg/errors"
+)
+
+// readOptionalFile reads path, returning (nil, nil) if it does not exist.
+func readOptionalFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// httpClientForSystemContext builds an *http.Client configured for mTLS
+// against a signing server, reusing the --cert-dir convention already used
+// for registry access: a "cert.pem"/"key.pem" pair for client auth and a
+// "ca.pem" to validate the server, if sys.DockerCertPath is set.
+func httpClientForSystemContext(sys *types.SystemContext) (*http.Client, error) {
+	if sys == nil || sys.DockerCertPath == "" {
+		return http.DefaultClient, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	cert, err := tls.LoadX509KeyPair(
+		filepath.Join(sys.DockerCertPath, "cert.pem"),
+		filepath.Join(sys.DockerCertPath, "key.pem"),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error loading client certificate from %s", sys.DockerCertPath)
+	}
+	tlsConfig.Certificates = []tls.Certificate{cert}
+
+	if pem, err := readOptionalFile(filepath.Join(sys.DockerCertPath, "ca.pem")); err == nil && pem != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("Error parsing CA certificate from %s", sys.DockerCertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}, nil
+}
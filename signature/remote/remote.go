@@ -0,0 +1,143 @@
+// Package remote implements a signature.SigningMechanism that delegates the
+// actual signing operation to an external process or an HTTPS signing
+// server, for use cases (HSMs, KMS, a central signing server) where the
+// private key must never reside on the host running skopeo.
+//
+// Verification is always performed locally: a remote mechanism only
+// replaces how a detached signature is produced, not how it is checked.
+package remote
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/types"
+	"github.com/pkg/errors"
+)
+
+// signer produces a detached signature for payload using the key identified
+// by keyIdentity (typically a GPG fingerprint or key ID).
+type signer interface {
+	sign(payload []byte, keyIdentity string) ([]byte, error)
+}
+
+// mechanism is a signature.SigningMechanism whose Sign method is delegated
+// to a signer, while verification-related methods are delegated to a local
+// mechanism.
+type mechanism struct {
+	local  signature.SigningMechanism
+	signer signer
+}
+
+// NewSigningMechanism returns a signature.SigningMechanism that signs using
+// the remote signer described by spec, and verifies locally using an
+// ephemeral GPG mechanism that starts with an empty keyring. sys is only
+// used by an http(s):// signer.
+//
+// spec is either:
+//   - "exec:/path/to/signer", invoking the program with the key identity as
+//     its first argument, the payload to sign on its stdin, and the detached
+//     signature expected on its stdout; or
+//   - "http://..."/"https://..." naming a signing server: the payload is
+//     POSTed as the request body (with the key identity in the
+//     X-Signing-Key-Identity header), and the response body is the detached
+//     signature. TLS client authentication and Basic auth are configured the
+//     same way as registry access, via sys.DockerCertPath and
+//     sys.DockerAuthConfig.
+func NewSigningMechanism(spec string, sys *types.SystemContext) (signature.SigningMechanism, error) {
+	local, _, err := signature.NewEphemeralGPGSigningMechanism([]byte{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error initializing a local mechanism for signature verification")
+	}
+
+	var s signer
+	switch {
+	case strings.HasPrefix(spec, "exec:"):
+		s = &execSigner{path: strings.TrimPrefix(spec, "exec:")}
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		s = &httpSigner{url: spec, sys: sys}
+	default:
+		local.Close()
+		return nil, errors.Errorf("%q is not a valid --sign-by-remote spec, expected exec:… or http(s)://…", spec)
+	}
+
+	return &mechanism{local: local, signer: s}, nil
+}
+
+func (m *mechanism) Sign(input []byte, keyIdentity string) ([]byte, error) {
+	return m.signer.sign(input, keyIdentity)
+}
+
+func (m *mechanism) Verify(unverifiedSignature []byte) (contents []byte, keyIdentity string, err error) {
+	return m.local.Verify(unverifiedSignature)
+}
+
+func (m *mechanism) UntrustedSignatureContents(untrustedSignature []byte) (untrustedContents []byte, shortKeyIdentifier string, err error) {
+	return m.local.UntrustedSignatureContents(untrustedSignature)
+}
+
+func (m *mechanism) SupportsSigning() error {
+	return nil
+}
+
+func (m *mechanism) Close() error {
+	return m.local.Close()
+}
+
+// execSigner signs by running an external program, writing the payload to
+// its stdin and reading the detached signature from its stdout.
+type execSigner struct {
+	path string
+}
+
+func (s *execSigner) sign(payload []byte, keyIdentity string) ([]byte, error) {
+	cmd := exec.Command(s.path, keyIdentity)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "Error running remote signer %q: %s", s.path, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+// httpSigner signs by POSTing the payload to a signing server.
+type httpSigner struct {
+	url string
+	sys *types.SystemContext
+}
+
+func (s *httpSigner) sign(payload []byte, keyIdentity string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error creating request for remote signer %q", s.url)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Signing-Key-Identity", keyIdentity)
+	if s.sys != nil && s.sys.DockerAuthConfig != nil {
+		req.SetBasicAuth(s.sys.DockerAuthConfig.Username, s.sys.DockerAuthConfig.Password)
+	}
+
+	client, err := httpClientForSystemContext(s.sys)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error contacting remote signer %q", s.url)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading response from remote signer %q", s.url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Remote signer %q returned status %s: %s", s.url, resp.Status, string(body))
+	}
+	return body, nil
+}